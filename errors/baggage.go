@@ -0,0 +1,97 @@
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+BaggageKey is the key under which Baggage records an error's
+fingerprint in a W3C baggage header.
+*/
+const BaggageKey = "go-errors.fingerprint"
+
+/*
+Fingerprint returns a short identifier for the place err was
+created, derived from the first frame of its stack. Errors
+created at the same call site share a fingerprint regardless
+of their message or prefixes. Returns an empty string if err
+is nil or has no stack.
+*/
+func Fingerprint(err error) string {
+	f, ok := origin(err)
+	if !ok {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(f.function + "\n" + f.file + "\n" + strconv.Itoa(f.line)))
+	return hex.EncodeToString(sum[:8])
+}
+
+/*
+Baggage returns a W3C baggage list member carrying err's
+fingerprint and origin, suitable for appending to the baggage
+header of outgoing requests:
+
+	go-errors.fingerprint=3f9a0c1e2b4d5a67;origin=pkg.function%20file.go:12
+
+Downstream services can read it back with ParseBaggage to
+correlate their own errors with the failure upstream. Returns
+an empty string if err is nil or has no stack.
+*/
+func Baggage(err error) string {
+	f, ok := origin(err)
+	if !ok {
+		return ""
+	}
+	o := f.function + " " + f.file + ":" + strconv.Itoa(f.line)
+	return BaggageKey + "=" + Fingerprint(err) + ";origin=" + url.PathEscape(o)
+}
+
+/*
+ParseBaggage looks for the member written by Baggage in a
+W3C baggage header, which may contain other members, and
+returns the fingerprint and origin it carries. The origin
+is empty if the member has no origin property. ok is false
+if the header has no such member.
+*/
+func ParseBaggage(header string) (fingerprint, origin string, ok bool) {
+
+	for _, member := range strings.Split(header, ",") {
+
+		parts := strings.Split(member, ";")
+		k, v, found := strings.Cut(parts[0], "=")
+		if !found || strings.TrimSpace(k) != BaggageKey {
+			continue
+		}
+
+		fingerprint = strings.TrimSpace(v)
+		for _, prop := range parts[1:] {
+			pk, pv, _ := strings.Cut(prop, "=")
+			if strings.TrimSpace(pk) != "origin" {
+				continue
+			}
+			if o, err := url.PathUnescape(strings.TrimSpace(pv)); err == nil {
+				origin = o
+			}
+		}
+		return fingerprint, origin, true
+	}
+
+	return "", "", false
+}
+
+// origin returns the frame at which err was created.
+func origin(err error) (frame, bool) {
+	custErr, ok := err.(*container)
+	if !ok {
+		return frame{}, false
+	}
+	stack := custErr.frames()
+	if len(stack) == 0 {
+		return frame{}, false
+	}
+	return stack[0], true
+}
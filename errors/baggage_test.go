@@ -0,0 +1,65 @@
+package errors
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newAtSameSite(msg string) error {
+	return New(msg)
+}
+
+func TestFingerprint(t *testing.T) {
+
+	if Fingerprint(nil) != "" {
+		t.Error("Expected empty fingerprint for nil.")
+	}
+	if Fingerprint(errors.New("hello")) != "" {
+		t.Error("Expected empty fingerprint for standard error.")
+	}
+
+	a := newAtSameSite("hello")
+	b := Prefix(newAtSameSite("awooo"), "yoo")
+	if Fingerprint(a) == "" || Fingerprint(a) != Fingerprint(b) {
+		t.Error("Expected errors from the same call site to share a fingerprint.")
+	}
+	if Fingerprint(a) == Fingerprint(New("hello")) {
+		t.Error("Expected errors from different call sites to differ.")
+	}
+	if Fingerprint(Compact(a)) != Fingerprint(a) {
+		t.Error("Compact changed the fingerprint.")
+	}
+}
+
+func TestBaggage(t *testing.T) {
+
+	if Baggage(nil) != "" {
+		t.Error("Expected empty baggage for nil.")
+	}
+
+	err := New("hello")
+	member := Baggage(err)
+	if !strings.HasPrefix(member, BaggageKey+"=") {
+		t.Errorf("Incorrect baggage member %q.", member)
+	}
+	if strings.ContainsAny(member, " ,") {
+		t.Errorf("Baggage member %q not encoded.", member)
+	}
+
+	header := "userId=alice, " + member + ", serverNode=DF%2028"
+	fp, o, ok := ParseBaggage(header)
+	if !ok {
+		t.Fatal("Baggage member not found.")
+	}
+	if fp != Fingerprint(err) {
+		t.Error("Incorrect fingerprint read from baggage.")
+	}
+	if !strings.Contains(o, "TestBaggage") {
+		t.Errorf("Incorrect origin %q read from baggage.", o)
+	}
+
+	if _, _, ok := ParseBaggage("userId=alice"); ok {
+		t.Error("Expected no member in unrelated baggage.")
+	}
+}
@@ -0,0 +1,30 @@
+package errors
+
+/*
+Compact returns a copy of err that does not retain the resolved
+frames of its stack trace. Errors from this package record their
+stack as program counters, 8 bytes per frame, and resolve them
+into function names, file paths and lines the first time the
+error is printed, keeping the result. Services that hold on to
+many errors after logging them, for example in caches or batch
+results, can compact them to release those frames.
+
+The returned error behaves identically to err; its frames are
+resolved again, and not kept, whenever it is printed. Errors not
+created by this package, and those already compacted, are
+returned as-is. Returns nil if err is nil.
+*/
+func Compact(err error) error {
+
+	custErr, ok := err.(*container)
+	if !ok || custErr.resolved == nil {
+		return err
+	}
+
+	return &container{
+		err:      custErr.err,
+		prefixes: custErr.prefixes,
+		ids:      custErr.ids,
+		stack:    custErr.stack,
+	}
+}
@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+
+	if Compact(nil) != nil {
+		t.Error("Expected nil return from Compact after passing nil.")
+	}
+
+	stdErr := errors.New("hello")
+	if Compact(stdErr) != stdErr {
+		t.Error("Expected standard error to be returned as-is.")
+	}
+
+	orig := Prefix(New("hello"), "yoo")
+	err := Compact(orig)
+
+	custErr, ok := err.(*container)
+	if !ok {
+		t.Fatal("Type assertion of custom error failed.")
+	}
+	if custErr.resolved != nil {
+		t.Error("Stack was not compacted.")
+	}
+	if Compact(err) != err {
+		t.Error("Expected compacted error to be returned as-is.")
+	}
+
+	if err.Error() != orig.Error() {
+		t.Error("Compact changed the error string.")
+	}
+	if !Equals(err, orig) {
+		t.Error("Compact changed the original error.")
+	}
+	if fmt.Sprintf("%+v", err) != fmt.Sprintf("%+v", orig) {
+		t.Error("Compacted error formatted differently to original.")
+	}
+	if custErr.resolved != nil {
+		t.Error("Printing a compacted error retained its frames.")
+	}
+
+	custOrig := orig.(*container)
+	if len(custOrig.resolved.frames) == 0 {
+		t.Error("Printing an error didn't retain its frames.")
+	}
+	if len(custOrig.stack) != cap(custOrig.stack) {
+		t.Error("Program counters not trimmed to their length.")
+	}
+}
+
+func BenchmarkCompact(b *testing.B) {
+	err := Prefix(New("hello"), "yoo")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Compact(err)
+	}
+}
+
+func BenchmarkFormatCompacted(b *testing.B) {
+	err := Compact(Prefix(New("hello"), "yoo"))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package errors
+
+import "sync/atomic"
+
+/*
+Config holds the settings that change how this package behaves.
+The zero value is not the default; use DefaultConfig as a
+starting point:
+
+	cfg := errors.DefaultConfig()
+	cfg.Strict = true
+	errors.SetConfig(cfg)
+*/
+type Config struct {
+
+	// Strict causes functions in this package to panic when
+	// they are misused. Currently this means calling AddStack
+	// on an error that already has a stack, or passing Prefix,
+	// PrefixF, PrefixID or AddStack an error interface that
+	// holds a nil pointer (a typed nil), which would otherwise
+	// be wrapped as though it were a real error.
+	Strict bool
+
+	// NestFormatters controls how the verbose form of an error
+	// is printed when the original error implements
+	// fmt.Formatter. When true the original error's own %+v
+	// output is printed in an indented block between the
+	// message and the stack trace. When false only the message
+	// is printed.
+	NestFormatters bool
+}
+
+/*
+DefaultConfig returns the settings the package starts with.
+*/
+func DefaultConfig() Config {
+	return Config{
+		NestFormatters: true,
+	}
+}
+
+var current atomic.Pointer[Config]
+
+func init() {
+	cfg := DefaultConfig()
+	current.Store(&cfg)
+}
+
+func config() *Config {
+	return current.Load()
+}
+
+/*
+CurrentConfig returns the settings currently in effect.
+*/
+func CurrentConfig() Config {
+	return *config()
+}
+
+/*
+SetConfig replaces the settings in effect. It is safe to call
+while other goroutines are creating or printing errors; each
+call to a function in this package sees either the old or the
+new settings in full.
+*/
+func SetConfig(cfg Config) {
+	current.Store(&cfg)
+}
+
+/*
+WithConfig calls fn with cfg in effect and restores the previous
+settings when fn returns or panics. The settings apply to the
+whole program while fn runs, not only to the calling goroutine,
+so calls to WithConfig should not overlap with each other or with
+code that relies on other settings, such as parallel tests.
+*/
+func WithConfig(cfg Config, fn func()) {
+	prev := current.Swap(&cfg)
+	defer current.Store(prev)
+	fn()
+}
@@ -0,0 +1,57 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestWithConfig(t *testing.T) {
+
+	def := DefaultConfig()
+	if CurrentConfig() != def {
+		t.Fatal("Expected default settings.")
+	}
+
+	cfg := Config{Strict: true}
+	WithConfig(cfg, func() {
+		if CurrentConfig() != cfg {
+			t.Error("Settings not in effect inside WithConfig.")
+		}
+	})
+	if CurrentConfig() != def {
+		t.Error("Settings not restored after WithConfig returned.")
+	}
+
+	func() {
+		defer func() { recover() }()
+		WithConfig(cfg, func() { panic("whoops") })
+	}()
+	if CurrentConfig() != def {
+		t.Error("Settings not restored after WithConfig panicked.")
+	}
+}
+
+// Run with -race.
+func TestSetConfigConcurrent(t *testing.T) {
+
+	def := CurrentConfig()
+	defer SetConfig(def)
+
+	err := Prefix(verboseErr{}, "yoo")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = fmt.Sprintf("%+v", err)
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		SetConfig(Config{NestFormatters: j%2 == 0})
+	}
+	wg.Wait()
+}
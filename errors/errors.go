@@ -75,20 +75,35 @@ If the error passed to a function is nil it will return nil. Errors
 that already have a stack will not have it replaced by calling AddStack
 or Prefix on them.
 
+Behaviour that isn't the same for every program, such as strict
+checking of misuse, is controlled by a Config. It can be set for the
+whole program with SetConfig or for the duration of a function with
+WithConfig, which is convenient in tests:
+
+	cfg := errors.DefaultConfig()
+	cfg.Strict = true
+	errors.WithConfig(cfg, func() {
+		// ...
+	})
+
 */
 package errors
 
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 type container struct {
 	err      error
 	prefixes []string
-	stack    []frame
+	ids      []message   // aligned with prefixes, nil if PrefixID was never used
+	stack    []uintptr   // program counters, resolved by frames
+	resolved *frameCache // nil once compacted
 }
 
 type frame struct {
@@ -114,8 +129,9 @@ func NewF(format string, a ...interface{}) error {
 
 func newErr(msg string) error {
 	return &container{
-		err:   errors.New(msg),
-		stack: stack(3),
+		err:      errors.New(msg),
+		stack:    stack(3),
+		resolved: new(frameCache),
 	}
 }
 
@@ -124,10 +140,10 @@ Prefix takes an error and annotates it with prefix to
 give more context, It also adds a stack trace from the point
 it was called if one doesn't already exist. The original
 error message is preserved and can be retrieved with Cause.
-Returns nil if err is nil.
+The error passed in is not modified. Returns nil if err is nil.
 */
 func Prefix(err error, prefix string) error {
-	return addPrefix(err, prefix)
+	return addPrefix(err, prefix, message{}, "Prefix")
 }
 
 /*
@@ -135,28 +151,54 @@ PrefixF is the same as Prefix and formats the prefix
 according to format.
 */
 func PrefixF(err error, format string, a ...interface{}) error {
-	return addPrefix(err, fmt.Sprintf(format, a...))
+	return addPrefix(err, fmt.Sprintf(format, a...), message{}, "PrefixF")
 }
 
-func addPrefix(err error, prefix string) error {
+// caller names the exported function for misuse reports.
+func addPrefix(err error, prefix string, msg message, caller string) error {
 
 	if err == nil {
 		return nil
 	}
+	if config().Strict && isTypedNil(err) {
+		misuse("%s called with a typed nil error (%T)", caller, err)
+	}
 
 	// Standard error.
 	custErr, ok := err.(*container)
 	if !ok {
-		return &container{
+		e := &container{
 			err:      err,
 			prefixes: []string{prefix},
 			stack:    stack(3),
+			resolved: new(frameCache),
 		}
+		if msg.id != "" {
+			e.ids = []message{msg}
+		}
+		return e
+	}
+
+	// One of ours. Containers are never modified once
+	// created so they can be shared between goroutines;
+	// prefixing returns a copy instead.
+	prefixes := make([]string, len(custErr.prefixes), len(custErr.prefixes)+1)
+	copy(prefixes, custErr.prefixes)
+
+	var ids []message
+	if custErr.ids != nil || msg.id != "" {
+		ids = make([]message, len(custErr.prefixes), len(custErr.prefixes)+1)
+		copy(ids, custErr.ids)
+		ids = append(ids, msg)
 	}
 
-	// One of ours.
-	custErr.prefixes = append(custErr.prefixes, prefix)
-	return custErr
+	return &container{
+		err:      custErr.err,
+		prefixes: append(prefixes, prefix),
+		ids:      ids,
+		stack:    custErr.stack,
+		resolved: custErr.resolved,
+	}
 }
 
 /*
@@ -169,16 +211,39 @@ func AddStack(err error) error {
 	if err == nil {
 		return nil
 	}
+	if config().Strict && isTypedNil(err) {
+		misuse("AddStack called with a typed nil error (%T)", err)
+	}
 	_, ok := err.(*container)
 	if !ok {
 		return &container{
-			err:   err,
-			stack: stack(2),
+			err:      err,
+			stack:    stack(2),
+			resolved: new(frameCache),
 		}
 	}
+	if config().Strict {
+		misuse("AddStack called on an error that already has a stack: %q", err.Error())
+	}
 	return err
 }
 
+// isTypedNil reports whether err is a non-nil interface
+// wrapping a nil value, e.g. a (*MyErr)(nil) returned as
+// an error.
+func isTypedNil(err error) bool {
+	v := reflect.ValueOf(err)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+func misuse(format string, a ...interface{}) {
+	panic("errors (strict mode): " + fmt.Sprintf(format, a...))
+}
+
 /*
 Cause retrieves the original error if it has been previously
 annotated with prefixes or a stack. Standard errors are returned
@@ -195,6 +260,76 @@ func Cause(err error) error {
 	return custErr.err
 }
 
+/*
+CauseN removes up to n layers of annotation from err, where the
+most recently added prefix is the outermost layer. Once all
+prefixes are removed the next layer is the stack, leaving the
+original error, and beyond that CauseN follows the Unwrap method
+of the original error if it has one:
+
+	err := errors.New("whoops")
+	err = errors.Prefix(err, "query")
+	err = errors.Prefix(err, "handler")
+
+	errors.CauseN(err, 1) // query: whoops (with stack)
+	errors.CauseN(err, 2) // whoops (with stack)
+	errors.CauseN(err, 3) // whoops (as returned by Cause)
+
+CauseN returns err if n is less than one and nil if err is nil.
+*/
+func CauseN(err error, n int) error {
+
+	for ; n > 0 && err != nil; n-- {
+
+		custErr, ok := err.(*container)
+		if !ok {
+			next := errors.Unwrap(err)
+			if next == nil {
+				return err
+			}
+			err = next
+			continue
+		}
+
+		last := len(custErr.prefixes) - 1
+		if last < 0 {
+			err = custErr.err
+			continue
+		}
+
+		e := &container{
+			err:      custErr.err,
+			prefixes: custErr.prefixes[:last],
+			stack:    custErr.stack,
+			resolved: custErr.resolved,
+		}
+		if custErr.ids != nil {
+			e.ids = custErr.ids[:last]
+		}
+		err = e
+	}
+
+	return err
+}
+
+/*
+Root returns the innermost error underlying err. It is like Cause
+but additionally follows the Unwrap method of the original error,
+including through errors from this package wrapped by others,
+until reaching one that does not wrap another. Root returns nil if
+err is nil.
+*/
+func Root(err error) error {
+	for {
+		err = Cause(err)
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
 /*
 Equals returns true if the original error value of err1 and err2
 is the same. Equivalent to:
@@ -221,11 +356,21 @@ func (e *container) Format(s fmt.State, verb rune) {
 
 		fmt.Fprintf(s, "Error: %s\n  │\n", e.Error())
 
-		for i, f := range e.stack {
+		if config().NestFormatters {
+			if block := nestedFormat(e.err); block != "" {
+				for _, line := range strings.Split(block, "\n") {
+					fmt.Fprintf(s, "  │    %s\n", line)
+				}
+				fmt.Fprint(s, "  │\n")
+			}
+		}
+
+		stack := e.frames()
+		for i, f := range stack {
 
 			start := "├─ "
 			fileStart := "│"
-			if i == len(e.stack)-1 {
+			if i == len(stack)-1 {
 				start = "└─ "
 				fileStart = " "
 			}
@@ -246,12 +391,58 @@ func (e *container) Format(s fmt.State, verb rune) {
 	}
 }
 
-func stack(skip int) []frame {
+// nestedFormat returns the verbose form of err if it
+// implements fmt.Formatter and says more than its message.
+// A leading line repeating the message is dropped as the
+// message is already in the header.
+func nestedFormat(err error) string {
+	if _, ok := err.(fmt.Formatter); !ok {
+		return ""
+	}
+	out := strings.TrimRight(fmt.Sprintf("%+v", err), "\n")
+	first, rest, _ := strings.Cut(out, "\n")
+	if first == err.Error() {
+		out = rest
+	}
+	return out
+}
 
+// stack records the program counters of the calling
+// goroutine's stack. skip is the number of frames to
+// leave out, counting stack itself, so as not to record
+// calls internal to this package.
+func stack(skip int) []uintptr {
 	pc := make([]uintptr, 16)
-	n := runtime.Callers(1, pc)
-	pc = pc[:n]
-	frames := runtime.CallersFrames(pc)
+	n := runtime.Callers(skip+1, pc)
+	return append([]uintptr(nil), pc[:n]...)
+}
+
+// frameCache holds the frames a stack resolves to once
+// they are first needed. It is shared by the copies of a
+// container made by Prefix, as their stacks are the same.
+type frameCache struct {
+	once   sync.Once
+	frames []frame
+}
+
+// frames returns the resolved stack of e. Frames are
+// cached unless e has been compacted.
+func (e *container) frames() []frame {
+	if e.resolved == nil {
+		return resolve(e.stack)
+	}
+	e.resolved.once.Do(func() {
+		e.resolved.frames = resolve(e.stack)
+	})
+	return e.resolved.frames
+}
+
+func resolve(pcs []uintptr) []frame {
+
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs)
 
 	var stack []frame
 
@@ -274,10 +465,5 @@ func stack(skip int) []frame {
 		}
 	}
 
-	// We skip these frames because they're
-	// calls internal to this package.
-	if len(stack) < skip {
-		return stack[0:0]
-	}
-	return stack[skip:]
+	return stack
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -223,3 +224,255 @@ func TestEquals(t *testing.T) {
 		t.Error("Expected false when comparing standard error to nil.")
 	}
 }
+
+type typedErr struct{}
+
+func (e *typedErr) Error() string { return "typed" }
+
+func TestStrict(t *testing.T) {
+
+	cfg := DefaultConfig()
+	cfg.Strict = true
+
+	var nilPtr *typedErr
+
+	cases := []struct {
+		name      string
+		fn        func()
+		wantPanic bool
+	}{
+		{"AddStack on custom error", func() { AddStack(New("hello")) }, true},
+		{"AddStack on typed nil", func() { AddStack(nilPtr) }, true},
+		{"Prefix on typed nil", func() { Prefix(nilPtr, "yoo") }, true},
+		{"PrefixID on typed nil", func() { PrefixID(nilPtr, "yoo") }, true},
+		{"AddStack on standard error", func() { AddStack(errors.New("hello")) }, false},
+		{"Prefix on custom error", func() { Prefix(New("hello"), "yoo") }, false},
+		{"Prefix on nil", func() { Prefix(nil, "yoo") }, false},
+	}
+
+	for _, c := range cases {
+		func() {
+			defer func() {
+				r := recover()
+				if c.wantPanic && r == nil {
+					t.Errorf("%s: expected panic in strict mode.", c.name)
+				}
+				if !c.wantPanic && r != nil {
+					t.Errorf("%s: unexpected panic in strict mode: %v", c.name, r)
+				}
+			}()
+			WithConfig(cfg, c.fn)
+		}()
+	}
+}
+
+func TestPrefixCopies(t *testing.T) {
+
+	err := New("hello")
+	a := Prefix(err, "a")
+	b := Prefix(err, "b")
+
+	if err.Error() != "hello" {
+		t.Error("Prefix modified the original error.")
+	}
+	if a.Error() != "a: hello" || b.Error() != "b: hello" {
+		t.Error("Prefixes leaked between errors sharing an original.")
+	}
+}
+
+// Run with -race.
+func TestConcurrentUse(t *testing.T) {
+
+	err := Prefix(New("hello"), "yoo")
+	want := fmt.Sprintf("%+v", err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p := PrefixF(err, "worker %d", i)
+				_ = p.Error()
+				_ = fmt.Sprintf("%+v", p)
+				_ = fmt.Sprintf("%s", err)
+				_ = New("hello")
+				_ = AddStack(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if fmt.Sprintf("%+v", err) != want {
+		t.Error("Shared error changed during concurrent use.")
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = New("hello")
+	}
+}
+
+func BenchmarkPrefix(b *testing.B) {
+	err := New("hello")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Prefix(err, "yoo")
+	}
+}
+
+func BenchmarkError(b *testing.B) {
+	err := Prefix(Prefix(New("hello"), "yoo"), "awooo")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = err.Error()
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	err := Prefix(New("hello"), "yoo")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", err)
+	}
+}
+
+func BenchmarkFormatParallel(b *testing.B) {
+	err := Prefix(New("hello"), "yoo")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = fmt.Sprintf("%+v", Prefix(err, "worker"))
+		}
+	})
+}
+
+type verboseErr struct{}
+
+func (e verboseErr) Error() string { return "verbose" }
+
+func (e verboseErr) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprint(s, "verbose\ndetail one\ndetail two\n")
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+func TestFormatNested(t *testing.T) {
+
+	err := Prefix(verboseErr{}, "yoo")
+
+	errStr := fmt.Sprintf("%+v", err)
+	if !strings.Contains(errStr, "  │    detail one\n  │    detail two\n") {
+		t.Errorf("Cause's verbose format not nested:\n%s", errStr)
+	}
+	if strings.Count(errStr, "verbose") != 1 {
+		t.Errorf("Cause's message repeated in nested format:\n%s", errStr)
+	}
+
+	cfg := DefaultConfig()
+	cfg.NestFormatters = false
+	WithConfig(cfg, func() {
+		errStr = fmt.Sprintf("%+v", err)
+	})
+	if strings.Contains(errStr, "detail") {
+		t.Errorf("Cause's verbose format nested when disabled:\n%s", errStr)
+	}
+
+	// A cause whose verbose form is just its message adds nothing.
+	errStr = fmt.Sprintf("%+v", New("hello"))
+	if strings.Count(errStr, "hello") != 1 {
+		t.Errorf("Plain cause printed twice:\n%s", errStr)
+	}
+}
+
+func TestCauseN(t *testing.T) {
+
+	if CauseN(nil, 1) != nil {
+		t.Error("Expected nil return from CauseN after passing nil.")
+	}
+
+	root := errors.New("hello")
+	wrapped := fmt.Errorf("wrapped: %w", root)
+
+	err := AddStack(wrapped)
+	err = PrefixID(err, "inner")
+	err = Prefix(err, "outer")
+
+	cases := []struct {
+		n    int
+		want string
+	}{
+		{0, "inner: outer: wrapped: hello"},
+		{1, "inner: wrapped: hello"},
+		{2, "wrapped: hello"},
+		{3, "wrapped: hello"},
+		{4, "hello"},
+		{10, "hello"},
+	}
+
+	for _, c := range cases {
+		got := CauseN(err, c.n)
+		if got.Error() != c.want {
+			t.Errorf("CauseN(err, %d) = %q, want %q.", c.n, got.Error(), c.want)
+		}
+	}
+
+	if _, ok := CauseN(err, 2).(*container); !ok {
+		t.Error("Expected stack to remain after removing all prefixes.")
+	}
+	if CauseN(err, 3) != wrapped {
+		t.Error("Expected original error after removing prefixes and stack.")
+	}
+	if CauseN(err, 4) != root {
+		t.Error("Expected unwrapped error.")
+	}
+	if err.Error() != "inner: outer: wrapped: hello" {
+		t.Error("CauseN modified the error passed to it.")
+	}
+}
+
+func TestRoot(t *testing.T) {
+
+	if Root(nil) != nil {
+		t.Error("Expected nil return from Root after passing nil.")
+	}
+
+	root := errors.New("hello")
+	err := Prefix(fmt.Errorf("wrapped: %w", root), "yoo")
+	if Root(err) != root {
+		t.Error("Error returned from Root not equal to innermost error.")
+	}
+	if Root(root) != root {
+		t.Error("Expected standard error to be returned as-is.")
+	}
+
+	// One of ours inside a standard wrapper.
+	custErr := New("hello")
+	err = fmt.Errorf("wrapped: %w", custErr)
+	if Root(err) != Cause(custErr) {
+		t.Error("Root stopped at custom error inside a standard wrapper.")
+	}
+	if Root(err) != CauseN(err, 10) {
+		t.Error("Root and CauseN disagree on the innermost error.")
+	}
+}
+
+func TestStrictNamesCaller(t *testing.T) {
+
+	cfg := DefaultConfig()
+	cfg.Strict = true
+
+	var nilPtr *typedErr
+
+	defer func() {
+		r := recover()
+		msg, _ := r.(string)
+		if !strings.Contains(msg, "PrefixID called") {
+			t.Errorf("Strict mode panic %q doesn't name PrefixID.", msg)
+		}
+	}()
+	WithConfig(cfg, func() { PrefixID(nilPtr, "yoo") })
+}
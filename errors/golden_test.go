@@ -0,0 +1,221 @@
+package errors
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+/*
+TestGolden renders each fixture in testdata/golden/*.in and
+compares the result with the matching .golden file. Run
+
+	go test -run TestGolden -update
+
+to rewrite the golden files after an intentional change to the
+formatter, then review the diff. Frames below the functions that
+build the fixture are replaced by a placeholder so that changes
+to the harness or the testing package don't alter the files.
+
+A fixture lists one instruction per line. Blank lines and lines
+starting with # are ignored. The error is built from the top down:
+
+	depth <n>           create the error n calls deep
+	new <msg>           errors.New
+	std <msg>           the standard library's errors.New
+	formatter <msg>     an error with its own multi-line %+v
+	prefix <text>       Prefix
+	prefixid <id> <a>…  PrefixID
+	addstack            AddStack
+	compact             Compact
+	verb <verb>         render with verb, may be repeated (default %+v)
+*/
+func TestGolden(t *testing.T) {
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "golden", "*.in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("No fixtures found.")
+	}
+
+	for _, path := range fixtures {
+
+		name := strings.TrimSuffix(filepath.Base(path), ".in")
+		t.Run(name, func(t *testing.T) {
+
+			got, err := renderFixture(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(path, ".in") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Rendering differs from %s.\n\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+type fixtureOp struct {
+	name string
+	arg  string
+}
+
+func renderFixture(path string) (string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var ops []fixtureOp
+	var verbs []string
+	depth := 0
+
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, arg, _ := strings.Cut(line, " ")
+
+		switch name {
+		case "depth":
+			if depth, err = strconv.Atoi(arg); err != nil {
+				return "", fmt.Errorf("%s:%d: %v", path, n, err)
+			}
+		case "verb":
+			verbs = append(verbs, arg)
+		case "new", "std", "formatter", "prefix", "prefixid", "addstack", "compact":
+			ops = append(ops, fixtureOp{name, arg})
+		default:
+			return "", fmt.Errorf("%s:%d: unknown instruction %q", path, n, name)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	if len(verbs) == 0 {
+		verbs = []string{"%+v"}
+	}
+
+	err = buildDeep(depth, ops)
+
+	var out strings.Builder
+	for _, verb := range verbs {
+		fmt.Fprintf(&out, "--- %s ---\n", verb)
+		out.WriteString(normalize(fmt.Sprintf(verb, err)))
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+// buildDeep adds depth frames to the stack before
+// building the error.
+func buildDeep(depth int, ops []fixtureOp) error {
+	if depth > 0 {
+		return buildDeep(depth-1, ops)
+	}
+	return build(ops)
+}
+
+func build(ops []fixtureOp) error {
+
+	var err error
+
+	for _, op := range ops {
+		switch op.name {
+		case "new":
+			err = New(op.arg)
+		case "std":
+			err = errors.New(op.arg)
+		case "formatter":
+			err = fixtureFormatter(op.arg)
+		case "prefix":
+			err = Prefix(err, op.arg)
+		case "prefixid":
+			fields := strings.Fields(op.arg)
+			args := make([]interface{}, len(fields)-1)
+			for i, a := range fields[1:] {
+				args[i] = a
+			}
+			err = PrefixID(err, fields[0], args...)
+		case "addstack":
+			err = AddStack(err)
+		case "compact":
+			err = Compact(err)
+		}
+	}
+
+	return err
+}
+
+type fixtureFormatter string
+
+func (e fixtureFormatter) Error() string { return string(e) }
+
+func (e fixtureFormatter) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s\n  detail: one\n  detail: two\n", string(e))
+		return
+	}
+	fmt.Fprint(s, string(e))
+}
+
+var (
+	framePath = regexp.MustCompile(`\S*?([^/\s]+\.go):\d+`)
+	frameFunc = regexp.MustCompile(`^(  [├└]─ \()\S*/`)
+	frameLine = regexp.MustCompile(`^  [├└]─ \((.*)\)$`)
+)
+
+// fixtureFrames are the functions that build fixture
+// errors. Frames below them belong to the harness and
+// the testing package and are not rendered.
+var fixtureFrames = map[string]bool{
+	"errors.build":     true,
+	"errors.buildDeep": true,
+}
+
+const harnessFrames = "  └─ (harness frames omitted)"
+
+// normalize removes the parts of a rendering that vary
+// between machines or with changes to the harness:
+// directories, package paths, line numbers, frames
+// outside the fixture and trailing whitespace.
+func normalize(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = framePath.ReplaceAllString(line, "$1:N")
+		line = frameFunc.ReplaceAllString(line, "$1")
+		if m := frameLine.FindStringSubmatch(line); m != nil && !fixtureFrames[m[1]] {
+			return strings.Join(append(lines[:i], harnessFrames, ""), "\n")
+		}
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,140 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+/*
+SchemaVersion is the version of the JSON interchange format
+produced by MarshalJSON and understood by Decode. It is bumped
+whenever a field changes meaning or a required field is added;
+adding optional fields does not change it.
+
+The format is described by schema/error.v1.json so that services
+written in other languages can emit errors that Go services are
+able to decode:
+
+	{
+		"version": 1,
+		"message": "whoops",
+		"prefixes": ["oh no", "db.query_failed(users)"],
+		"ids": [null, {"id": "db.query_failed", "args": ["users"]}],
+		"stack": [
+			{"function": "package.function", "file": "file.go", "line": 12}
+		]
+	}
+
+Prefixes are listed innermost first, the order in which they were
+added. If present, ids has one entry per prefix: null for literal
+prefixes and the message ID and arguments for those added with
+PrefixID. Arguments are sent as strings. Only version and message
+are required.
+*/
+const SchemaVersion = 1
+
+type jsonError struct {
+	Version  int            `json:"version"`
+	Message  *string        `json:"message"`
+	Prefixes []string       `json:"prefixes,omitempty"`
+	IDs      []*jsonMessage `json:"ids,omitempty"`
+	Stack    []jsonFrame    `json:"stack,omitempty"`
+}
+
+type jsonMessage struct {
+	ID   string   `json:"id"`
+	Args []string `json:"args,omitempty"`
+}
+
+type jsonFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+/*
+MarshalJSON encodes the error in the interchange format
+described by SchemaVersion.
+*/
+func (e *container) MarshalJSON() ([]byte, error) {
+	msg := e.err.Error()
+	je := jsonError{
+		Version:  SchemaVersion,
+		Message:  &msg,
+		Prefixes: e.prefixes,
+	}
+	if e.ids != nil {
+		je.IDs = make([]*jsonMessage, len(e.ids))
+		for i, m := range e.ids {
+			if m.id == "" {
+				continue
+			}
+			jm := &jsonMessage{ID: m.id}
+			for _, a := range m.args {
+				jm.Args = append(jm.Args, fmt.Sprint(a))
+			}
+			je.IDs[i] = jm
+		}
+	}
+	for _, f := range e.frames() {
+		je.Stack = append(je.Stack, jsonFrame{
+			Function: f.function,
+			File:     f.file,
+			Line:     f.line,
+		})
+	}
+	return json.Marshal(je)
+}
+
+/*
+Decode parses data in the interchange format described by
+SchemaVersion and returns the error it describes, with a stack
+trace beginning at the call site of Decode. The stack recorded
+by the sender is not retained. Unknown fields are ignored, as
+adding optional fields does not change the version, but versions
+newer than SchemaVersion are rejected.
+
+The second return value is non-nil if data could not be decoded.
+*/
+func Decode(data []byte) (error, error) {
+
+	var je jsonError
+	if err := json.Unmarshal(data, &je); err != nil {
+		return nil, fmt.Errorf("errors: decoding error: %v", err)
+	}
+	if je.Version == 0 {
+		return nil, errors.New("errors: decoding error: missing version")
+	}
+	if je.Message == nil {
+		return nil, errors.New("errors: decoding error: missing message")
+	}
+	if je.Version < 1 || je.Version > SchemaVersion {
+		return nil, fmt.Errorf("errors: decoding error: unsupported version %d (supported: 1 to %d)", je.Version, SchemaVersion)
+	}
+
+	if je.IDs != nil && len(je.IDs) != len(je.Prefixes) {
+		return nil, fmt.Errorf("errors: decoding error: %d ids for %d prefixes", len(je.IDs), len(je.Prefixes))
+	}
+
+	e := &container{
+		err:      errors.New(*je.Message),
+		prefixes: je.Prefixes,
+		stack:    stack(2),
+		resolved: new(frameCache),
+	}
+	if je.IDs != nil {
+		e.ids = make([]message, len(je.IDs))
+	}
+	for i, jm := range je.IDs {
+		if jm == nil {
+			continue
+		}
+		m := message{id: jm.ID}
+		for _, a := range jm.Args {
+			m.args = append(m.args, a)
+		}
+		e.ids[i] = m
+	}
+	return e, nil
+}
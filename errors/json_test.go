@@ -0,0 +1,125 @@
+package errors
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+
+	err := Prefix(New("hello"), "yoo")
+
+	b, jErr := json.Marshal(err)
+	if jErr != nil {
+		t.Fatal(jErr)
+	}
+
+	var je jsonError
+	if jErr := json.Unmarshal(b, &je); jErr != nil {
+		t.Fatal(jErr)
+	}
+
+	if je.Version != SchemaVersion {
+		t.Error("Incorrect schema version.")
+	}
+	if je.Message == nil || *je.Message != "hello" {
+		t.Error("Incorrect message.")
+	}
+	if len(je.Prefixes) != 1 || je.Prefixes[0] != "yoo" {
+		t.Error("Incorrect prefixes.")
+	}
+	if len(je.Stack) == 0 {
+		t.Error("No stack.")
+	}
+}
+
+func TestDecode(t *testing.T) {
+
+	cases := []struct {
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{`{"version": 1, "message": "hello"}`, "hello", false},
+		{`{"version": 1, "message": "hello", "prefixes": ["a", "b"]}`, "a: b: hello", false},
+		{`{"version": 1, "message": "hello", "severity": "high"}`, "hello", false}, // Unknown optional field.
+		{`{"message": "hello"}`, "", true},
+		{`{"version": 1}`, "", true},
+		{`{"version": 1, "message": ""}`, "", false},      // Present but empty.
+		{`{"version": 99, "message": "hello"}`, "", true}, // Newer than supported.
+		{`{"version": -3, "message": "hello"}`, "", true},
+		{`not json`, "", true},
+	}
+
+	for _, c := range cases {
+
+		err, decErr := Decode([]byte(c.data))
+		if c.wantErr {
+			if decErr == nil {
+				t.Errorf("Expected decoding error for %s.", c.data)
+			}
+			continue
+		}
+		if decErr != nil {
+			t.Errorf("Unexpected decoding error for %s: %v", c.data, decErr)
+			continue
+		}
+
+		if err.Error() != c.want {
+			t.Errorf("Incorrect error string %q, want %q.", err.Error(), c.want)
+		}
+		custErr, ok := err.(*container)
+		if !ok {
+			t.Error("Type assertion of custom error failed.")
+			continue
+		}
+		if len(custErr.stack) == 0 {
+			t.Error("No local stack.")
+		}
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+
+	orig := Prefix(Prefix(New("hello"), "inner"), "outer")
+	b, jErr := json.Marshal(orig)
+	if jErr != nil {
+		t.Fatal(jErr)
+	}
+
+	err, decErr := Decode(b)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if err.Error() != orig.Error() {
+		t.Errorf("Round trip changed error string from %q to %q.", orig.Error(), err.Error())
+	}
+}
+
+func TestDecodeIDs(t *testing.T) {
+
+	orig := Prefix(New("hello"), "yoo")
+	orig = PrefixID(orig, "db.query_failed", "users", 2)
+
+	b, jErr := json.Marshal(orig)
+	if jErr != nil {
+		t.Fatal(jErr)
+	}
+
+	err, decErr := Decode(b)
+	if decErr != nil {
+		t.Fatal(decErr)
+	}
+	if err.Error() != orig.Error() {
+		t.Errorf("Round trip changed error string from %q to %q.", orig.Error(), err.Error())
+	}
+
+	cat := testCatalog{"db.query_failed": "query on %v failed %v times"}
+	if got, want := Localize(err, cat), Localize(orig, cat); got != want {
+		t.Errorf("Round trip changed localized string from %q to %q.", want, got)
+	}
+
+	if _, decErr := Decode([]byte(`{"version": 1, "message": "hello", "prefixes": ["a"], "ids": [null, null]}`)); decErr == nil {
+		t.Error("Expected decoding error for mismatched ids and prefixes.")
+	}
+}
@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Catalog resolves message IDs added with PrefixID into text for
+users, typically in their own language. Message reports false
+if the catalog has no entry for id.
+*/
+type Catalog interface {
+	Message(id string, a ...interface{}) (string, bool)
+}
+
+type message struct {
+	id   string
+	args []interface{}
+}
+
+/*
+PrefixID is like Prefix but annotates err with a message ID and
+arguments instead of literal text:
+
+	err = errors.PrefixID(err, "db.query_failed", table)
+
+The prefix is resolved against a Catalog only when the error is
+rendered for users with Localize. Everywhere else, including
+Error and Format, it is rendered as the ID followed by its
+arguments:
+
+	db.query_failed(users): connection refused
+
+Message IDs survive MarshalJSON and Decode, with their arguments
+converted to strings. Returns nil if err is nil.
+*/
+func PrefixID(err error, id string, a ...interface{}) error {
+	msg := message{id: id, args: a}
+	return addPrefix(err, msg.String(), msg, "PrefixID")
+}
+
+func (m message) String() string {
+	if len(m.args) == 0 {
+		return m.id
+	}
+	args := make([]string, len(m.args))
+	for i, a := range m.args {
+		args[i] = fmt.Sprint(a)
+	}
+	return m.id + "(" + strings.Join(args, ", ") + ")"
+}
+
+/*
+Localize returns the message of err with prefixes added by
+PrefixID resolved against c. Prefixes the catalog has no entry
+for, and those added by Prefix or PrefixF, are rendered as they
+are by Error. The original error's message is never translated.
+A nil c is treated as a catalog with no entries. Returns an empty
+string if err is nil.
+*/
+func Localize(err error, c Catalog) string {
+
+	if err == nil {
+		return ""
+	}
+	custErr, ok := err.(*container)
+	if !ok || c == nil {
+		return err.Error()
+	}
+
+	var s string
+	for i, p := range custErr.prefixes {
+		if custErr.ids != nil && custErr.ids[i].id != "" {
+			m := custErr.ids[i]
+			if text, ok := c.Message(m.id, m.args...); ok {
+				p = text
+			}
+		}
+		s += p + ": "
+	}
+	return s + custErr.err.Error()
+}
@@ -0,0 +1,84 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type testCatalog map[string]string
+
+func (c testCatalog) Message(id string, a ...interface{}) (string, bool) {
+	format, ok := c[id]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(format, a...), true
+}
+
+func TestPrefixID(t *testing.T) {
+
+	if PrefixID(nil, "db.query_failed") != nil {
+		t.Error("Expected nil return from PrefixID after passing nil.")
+	}
+
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{PrefixID(errors.New("hello"), "db.query_failed", "users"), "db.query_failed(users): hello"},
+		{PrefixID(New("hello"), "db.query_failed", "users", 2), "db.query_failed(users, 2): hello"},
+		{PrefixID(New("hello"), "db.closed"), "db.closed: hello"},
+	}
+
+	for _, c := range cases {
+
+		if c.err.Error() != c.want {
+			t.Errorf("Incorrect error string %q, want %q.", c.err.Error(), c.want)
+		}
+
+		custErr, ok := c.err.(*container)
+		if !ok {
+			t.Error("Type assertion of custom error failed.")
+			continue
+		}
+		if len(custErr.stack) == 0 {
+			t.Error("No stack.")
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+
+	cat := testCatalog{
+		"db.query_failed": "la requête sur %s a échoué",
+	}
+
+	err := New("hello")
+	err = PrefixID(err, "db.query_failed", "users")
+	err = Prefix(err, "yoo")
+	err = PrefixID(err, "db.unknown")
+
+	want := "la requête sur users a échoué: yoo: db.unknown: hello"
+	if got := Localize(err, cat); got != want {
+		t.Errorf("Incorrect localized string %q, want %q.", got, want)
+	}
+
+	want = "db.query_failed(users): yoo: db.unknown: hello"
+	if err.Error() != want {
+		t.Errorf("Incorrect error string %q, want %q.", err.Error(), want)
+	}
+
+	if Localize(Prefix(New("hello"), "yoo"), cat) != "yoo: hello" {
+		t.Error("Incorrect localized string for error without message IDs.")
+	}
+	if Localize(errors.New("hello"), cat) != "hello" {
+		t.Error("Incorrect localized string for standard error.")
+	}
+	if Localize(err, nil) != err.Error() {
+		t.Error("Expected error string when localizing with a nil catalog.")
+	}
+	if Localize(nil, cat) != "" {
+		t.Error("Expected empty string for nil.")
+	}
+}